@@ -0,0 +1,202 @@
+// Package binread provides panic-free little-endian binary reading
+// primitives for parsing untrusted, length-prefixed binary formats such as
+// LNK files. Methods report short or malformed input as an error instead of
+// panicking. Bytes returns io.ErrUnexpectedEOF itself; the other methods
+// include the underlying error's text in their own message without wrapping
+// it. NullString and NullUnicodeString treat the end of input as a
+// terminator rather than an error.
+package binread
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// Reader reads little-endian primitives from an underlying io.Reader.
+type Reader struct {
+	r io.Reader
+}
+
+// New returns a Reader that reads from r.
+func New(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// NewBytes returns a Reader positioned at the start of b.
+func NewBytes(b []byte) *Reader {
+	return New(bytes.NewReader(b))
+}
+
+// U16 reads a little-endian uint16.
+func (r *Reader) U16() (uint16, error) {
+	var v uint16
+	if err := binary.Read(r.r, binary.LittleEndian, &v); err != nil {
+		return 0, fmt.Errorf("binread: read uint16 - %s", err.Error())
+	}
+	return v, nil
+}
+
+// U32 reads a little-endian uint32.
+func (r *Reader) U32() (uint32, error) {
+	var v uint32
+	if err := binary.Read(r.r, binary.LittleEndian, &v); err != nil {
+		return 0, fmt.Errorf("binread: read uint32 - %s", err.Error())
+	}
+	return v, nil
+}
+
+// U64 reads a little-endian uint64.
+func (r *Reader) U64() (uint64, error) {
+	var v uint64
+	if err := binary.Read(r.r, binary.LittleEndian, &v); err != nil {
+		return 0, fmt.Errorf("binread: read uint64 - %s", err.Error())
+	}
+	return v, nil
+}
+
+// Bytes reads exactly n bytes. If fewer than n bytes are available it
+// returns io.ErrUnexpectedEOF rather than silently truncating the result.
+func (r *Reader) Bytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("binread: read bytes - %w: negative length %d", io.ErrUnexpectedEOF, n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("binread: read %d bytes - %s", n, err.Error())
+	}
+	return b, nil
+}
+
+// NullString reads bytes until a 0x00 terminator, or the underlying Reader
+// is exhausted, and returns them as a string. The terminator is consumed
+// but not included in the result.
+func (r *Reader) NullString() (string, error) {
+	var b []byte
+	one := make([]byte, 1)
+	for {
+		_, err := io.ReadFull(r.r, one)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("binread: read byte - %s", err.Error())
+		}
+		if one[0] == 0x00 {
+			break
+		}
+		b = append(b, one[0])
+	}
+	return string(b), nil
+}
+
+// NullUnicodeString reads UTF-16LE code unit pairs until a 0x0000
+// terminator, or the underlying Reader is exhausted, honoring surrogate
+// pairs for characters outside the Basic Multilingual Plane.
+func (r *Reader) NullUnicodeString() (string, error) {
+	var units []uint16
+	pair := make([]byte, 2)
+	for {
+		_, err := io.ReadFull(r.r, pair)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("binread: read code unit - %s", err.Error())
+		}
+		if pair[0] == 0x00 && pair[1] == 0x00 {
+			break
+		}
+		units = append(units, binary.LittleEndian.Uint16(pair))
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// SizedString reads a uint16 character count followed by that many
+// characters (doubled for unicode), as used by LNK StringData structures.
+// The result is not null-terminated. Unicode characters outside the Basic
+// Multilingual Plane, encoded as surrogate pairs, are decoded correctly.
+func (r *Reader) SizedString(unicode bool) (string, error) {
+	count, err := r.U16()
+	if err != nil {
+		return "", fmt.Errorf("read size - %s", err.Error())
+	}
+	byteLen := int(count)
+	if unicode {
+		byteLen *= 2
+	}
+	b, err := r.Bytes(byteLen)
+	if err != nil {
+		return "", fmt.Errorf("read bytes - %s", err.Error())
+	}
+	if !unicode {
+		return string(b), nil
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// Section reads a size from the start of r. The size length is chosen by
+// sSize: 2 for a uint16, 4 for a uint32, 8 for a uint64. It then reads
+// (size-sSize) bytes, bounded by maxSize, and returns the size bytes
+// followed by the payload as data, a fresh Reader over the payload, and
+// the section size.
+func (r *Reader) Section(sSize int, maxSize uint64) (data []byte, nr io.Reader, size int, err error) {
+	var sectionSize uint64
+	var sizeBytes []byte
+	switch sSize {
+	case 2:
+		v, err := r.U16()
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("read size %d bytes - %s", sSize, err.Error())
+		}
+		sectionSize = uint64(v)
+		sizeBytes = make([]byte, 2)
+		binary.LittleEndian.PutUint16(sizeBytes, v)
+	case 4:
+		v, err := r.U32()
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("read size %d bytes - %s", sSize, err.Error())
+		}
+		sectionSize = uint64(v)
+		sizeBytes = make([]byte, 4)
+		binary.LittleEndian.PutUint32(sizeBytes, v)
+	case 8:
+		v, err := r.U64()
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("read size %d bytes - %s", sSize, err.Error())
+		}
+		sectionSize = v
+		sizeBytes = make([]byte, 8)
+		binary.LittleEndian.PutUint64(sizeBytes, v)
+	default:
+		return nil, nil, 0, fmt.Errorf("invalid sSize - got %v", sSize)
+	}
+
+	if sectionSize < uint64(sSize) {
+		return nil, nil, 0, fmt.Errorf("section size %d smaller than the %d-byte size field itself", sectionSize, sSize)
+	}
+	computedSize := sectionSize - uint64(sSize)
+	if computedSize > maxSize {
+		return nil, nil, 0, fmt.Errorf("invalid computed size got %d; expected a size < %d", computedSize, maxSize)
+	}
+
+	payload, err := r.Bytes(int(computedSize))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("read section payload - %s", err.Error())
+	}
+
+	data = append(sizeBytes, payload...)
+	return data, bytes.NewReader(payload), int(sectionSize), nil
+}
@@ -0,0 +1,106 @@
+package binread
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16LE is a test-local helper so this package's tests do not
+// depend on the lnk package that wraps it.
+func encodeUTF16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[i*2:], u)
+	}
+	return b
+}
+
+func TestSizedStringUnicodeSurrogatePairs(t *testing.T) {
+	s := "hi \U0001F600!"
+	enc := encodeUTF16LE(s)
+
+	var buf bytes.Buffer
+	var count [2]byte
+	binary.LittleEndian.PutUint16(count[:], uint16(len(enc)/2))
+	buf.Write(count[:])
+	buf.Write(enc)
+
+	got, err := New(&buf).SizedString(true)
+	if err != nil {
+		t.Fatalf("SizedString: %v", err)
+	}
+	if got != s {
+		t.Fatalf("SizedString = %q, want %q", got, s)
+	}
+}
+
+func TestNullUnicodeStringSurrogatePairs(t *testing.T) {
+	s := "\U0001F600\U0001F602"
+	buf := bytes.NewBuffer(encodeUTF16LE(s))
+	buf.Write([]byte{0x00, 0x00})
+
+	got, err := New(buf).NullUnicodeString()
+	if err != nil {
+		t.Fatalf("NullUnicodeString: %v", err)
+	}
+	if got != s {
+		t.Fatalf("NullUnicodeString = %q, want %q", got, s)
+	}
+}
+
+// TestShortInputReturnsErrorNotPanic is the whole reason this package
+// exists: every primitive must report an error on short or malformed
+// input instead of panicking.
+func TestShortInputReturnsErrorNotPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("panicked instead of returning an error: %v", r)
+		}
+	}()
+
+	if _, err := New(bytes.NewReader(nil)).U16(); err == nil {
+		t.Error("U16 on empty input: want error, got nil")
+	}
+	if _, err := New(bytes.NewReader([]byte{0x01})).U16(); err == nil {
+		t.Error("U16 on 1 byte: want error, got nil")
+	}
+	if _, err := New(bytes.NewReader([]byte{0x01, 0x02, 0x03})).U32(); err == nil {
+		t.Error("U32 on 3 bytes: want error, got nil")
+	}
+	if _, err := New(bytes.NewReader([]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07})).U64(); err == nil {
+		t.Error("U64 on 7 bytes: want error, got nil")
+	}
+
+	_, err := New(bytes.NewReader([]byte{0x01, 0x02})).Bytes(10)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Bytes(10) on 2 bytes: want io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	if _, err := New(bytes.NewReader([]byte{0x01, 0x02})).Bytes(-1); err == nil {
+		t.Error("Bytes(-1): want error, got nil")
+	}
+
+	if _, _, _, err := New(bytes.NewReader(nil)).Section(4, 1<<20); err == nil {
+		t.Error("Section on empty input: want error, got nil")
+	}
+	// A section that declares itself smaller than its own size field.
+	tooSmall := make([]byte, 4)
+	binary.LittleEndian.PutUint32(tooSmall, 2)
+	if _, _, _, err := New(bytes.NewReader(tooSmall)).Section(4, 1<<20); err == nil {
+		t.Error("Section with size smaller than sSize: want error, got nil")
+	}
+	// A section whose declared payload size is truncated in the input.
+	truncated := make([]byte, 4)
+	binary.LittleEndian.PutUint32(truncated, 100)
+	if _, _, _, err := New(bytes.NewReader(truncated)).Section(4, 1<<20); err == nil {
+		t.Error("Section with truncated payload: want error, got nil")
+	}
+	// An invalid sSize.
+	if _, _, _, err := New(bytes.NewReader(tooSmall)).Section(3, 1<<20); err == nil {
+		t.Error("Section with invalid sSize: want error, got nil")
+	}
+}
@@ -0,0 +1,181 @@
+package lnk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gorpher/golnk/binread"
+)
+
+// Standard ExtraDataBlock signatures (MS-SHLLINK section 2.5.1).
+const (
+	SigEnvironmentVariableDataBlock = 0xA0000001
+	SigConsoleDataBlock             = 0xA0000002
+	SigTrackerDataBlock             = 0xA0000003
+	SigConsoleFEDataBlock           = 0xA0000004
+	SigSpecialFolderDataBlock       = 0xA0000005
+	SigDarwinDataBlock              = 0xA0000006
+	SigIconEnvironmentDataBlock     = 0xA0000007
+	SigShimDataBlock                = 0xA0000008
+	SigPropertyStoreDataBlock       = 0xA0000009
+	SigKnownFolderDataBlock         = 0xA000000B
+	SigVistaAndAboveIDListDataBlock = 0xA000000C
+)
+
+// ExtraDataBlockDecoder decodes the payload of an ExtraData block - the
+// bytes after its 4-byte size and 4-byte signature - into an
+// ExtraDataBlock.
+type ExtraDataBlockDecoder func(r io.Reader) (ExtraDataBlock, error)
+
+var extraDataRegistry = map[uint32]ExtraDataBlockDecoder{}
+
+// RegisterExtraDataBlock registers decode as the decoder for ExtraData
+// blocks with the given signature, overwriting any previous registration
+// for that signature. Third parties can use this to add support for
+// vendor-specific blocks without patching golnk itself.
+func RegisterExtraDataBlock(sig uint32, decode ExtraDataBlockDecoder) {
+	extraDataRegistry[sig] = decode
+}
+
+func init() {
+	RegisterExtraDataBlock(SigConsoleDataBlock, rawBlockDecoder(func(b []byte) ExtraDataBlock { return ConsoleDataBlock{Raw: b} }))
+	RegisterExtraDataBlock(SigTrackerDataBlock, rawBlockDecoder(func(b []byte) ExtraDataBlock { return TrackerDataBlock{Raw: b} }))
+	RegisterExtraDataBlock(SigPropertyStoreDataBlock, rawBlockDecoder(func(b []byte) ExtraDataBlock { return PropertyStoreDataBlock{Raw: b} }))
+	RegisterExtraDataBlock(SigEnvironmentVariableDataBlock, rawBlockDecoder(func(b []byte) ExtraDataBlock { return EnvironmentVariableDataBlock{Raw: b} }))
+}
+
+// rawBlockDecoder builds an ExtraDataBlockDecoder that reads the whole
+// payload and hands it to wrap, for blocks golnk does not yet decompose
+// field by field.
+func rawBlockDecoder(wrap func([]byte) ExtraDataBlock) ExtraDataBlockDecoder {
+	return func(r io.Reader) (ExtraDataBlock, error) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return wrap(b), nil
+	}
+}
+
+// ConsoleDataBlock holds console window properties (MS-SHLLINK 2.5.2). The
+// payload is kept raw until golnk decomposes it field by field.
+type ConsoleDataBlock struct{ Raw []byte }
+
+func (b ConsoleDataBlock) Signature() uint32       { return SigConsoleDataBlock }
+func (b ConsoleDataBlock) Encode() ([]byte, error) { return b.Raw, nil }
+
+// TrackerDataBlock holds distributed link tracking data, including the
+// forensic droid volume and machine IDs (MS-SHLLINK 2.5.10).
+type TrackerDataBlock struct{ Raw []byte }
+
+func (b TrackerDataBlock) Signature() uint32       { return SigTrackerDataBlock }
+func (b TrackerDataBlock) Encode() ([]byte, error) { return b.Raw, nil }
+
+// PropertyStoreDataBlock holds a serialized property storage
+// (MS-SHLLINK 2.5.7 / MS-PROPSTORE).
+type PropertyStoreDataBlock struct{ Raw []byte }
+
+func (b PropertyStoreDataBlock) Signature() uint32       { return SigPropertyStoreDataBlock }
+func (b PropertyStoreDataBlock) Encode() ([]byte, error) { return b.Raw, nil }
+
+// EnvironmentVariableDataBlock holds a path with environment variables
+// unexpanded (MS-SHLLINK 2.5.4).
+type EnvironmentVariableDataBlock struct{ Raw []byte }
+
+func (b EnvironmentVariableDataBlock) Signature() uint32       { return SigEnvironmentVariableDataBlock }
+func (b EnvironmentVariableDataBlock) Encode() ([]byte, error) { return b.Raw, nil }
+
+// RawDataBlock is yielded by ExtraDataIterator for signatures with no
+// registered decoder, so iteration never fails on an unrecognized block.
+type RawDataBlock struct {
+	Sig     uint32
+	Payload []byte
+}
+
+func (b RawDataBlock) Signature() uint32       { return b.Sig }
+func (b RawDataBlock) Encode() ([]byte, error) { return b.Payload, nil }
+
+// ExtraDataIterator walks the ExtraData blocks of a LNK file in order,
+// reading a size, then that many bytes, then dispatching by signature,
+// and stopping at the terminal 0x00000000 block.
+type ExtraDataIterator struct {
+	r    io.Reader
+	err  error
+	done bool
+}
+
+// NewExtraDataIterator returns an iterator over the ExtraData region read
+// from r - typically an io.SectionReader positioned at the offset found by
+// ParseAt's backward scan.
+func NewExtraDataIterator(r io.Reader) *ExtraDataIterator {
+	return &ExtraDataIterator{r: r}
+}
+
+// Next reads the next block's size and signature and returns a reader over
+// its payload - the bytes after the size and signature. ok is false once
+// the terminal block is reached or an error occurs; use Err to tell them
+// apart.
+func (it *ExtraDataIterator) Next() (sig uint32, size uint32, payload io.Reader, ok bool) {
+	if it.done || it.err != nil {
+		return 0, 0, nil, false
+	}
+
+	br := binread.New(it.r)
+	blockSize, err := br.U32()
+	if err != nil {
+		it.err = fmt.Errorf("golnk.ExtraDataIterator: read block size - %s", err.Error())
+		return 0, 0, nil, false
+	}
+	if blockSize == 0 {
+		it.done = true
+		return 0, 0, nil, false
+	}
+	if blockSize < 8 {
+		it.err = fmt.Errorf("golnk.ExtraDataIterator: invalid block size %d", blockSize)
+		return 0, 0, nil, false
+	}
+
+	rest, err := br.Bytes(int(blockSize) - 4)
+	if err != nil {
+		it.err = fmt.Errorf("golnk.ExtraDataIterator: read block - %s", err.Error())
+		return 0, 0, nil, false
+	}
+
+	sig = binary.LittleEndian.Uint32(rest)
+	return sig, blockSize, bytes.NewReader(rest[4:]), true
+}
+
+// Err returns the first error encountered while iterating, if any.
+func (it *ExtraDataIterator) Err() error {
+	return it.err
+}
+
+// Decode drains the iterator, decoding each block with the decoder
+// registered for its signature via RegisterExtraDataBlock, or falling back
+// to a RawDataBlock when none is registered.
+func (it *ExtraDataIterator) Decode() ([]ExtraDataBlock, error) {
+	var blocks []ExtraDataBlock
+	for {
+		sig, size, payload, ok := it.Next()
+		if !ok {
+			break
+		}
+		decode, registered := extraDataRegistry[sig]
+		if !registered {
+			b, err := io.ReadAll(payload)
+			if err != nil {
+				return nil, fmt.Errorf("golnk.ExtraDataIterator: read unregistered block 0x%08X - %s", sig, err.Error())
+			}
+			blocks = append(blocks, RawDataBlock{Sig: sig, Payload: b})
+			continue
+		}
+		block, err := decode(payload)
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ExtraDataIterator: decode block 0x%08X (size %d) - %s", sig, size, err.Error())
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, it.Err()
+}
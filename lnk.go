@@ -0,0 +1,69 @@
+package lnk
+
+// ShellLinkHeader is the fixed-size header that begins every LNK file
+// (MS-SHLLINK section 2.1).
+type ShellLinkHeader struct {
+	HeaderSize     uint32
+	LinkCLSID      [16]byte
+	LinkFlags      uint32
+	FileAttributes uint32
+	CreationTime   uint64
+	AccessTime     uint64
+	WriteTime      uint64
+	FileSize       uint32
+	IconIndex      int32
+	ShowCommand    uint32
+	HotKey         uint16
+	Reserved1      uint16
+	Reserved2      uint32
+	Reserved3      uint32
+}
+
+// LinkTargetIDList is the optional IDList structure that follows the header
+// when the HasLinkTargetIDList flag is set.
+type LinkTargetIDList struct {
+	IDListSize uint16
+	ItemIDList []byte
+}
+
+// LinkInfo is the optional LinkInfo structure (MS-SHLLINK section 2.3).
+// The raw payload is kept as-is; golnk does not yet decompose it further.
+type LinkInfo struct {
+	Raw []byte
+}
+
+// StringData holds the optional NAME_STRING, RELATIVE_PATH, WORKING_DIR,
+// COMMAND_LINE_ARGUMENTS and ICON_LOCATION strings (MS-SHLLINK section 2.4).
+type StringData struct {
+	NameString           string
+	RelativePath         string
+	WorkingDir           string
+	CommandLineArguments string
+	IconLocation         string
+}
+
+// ExtraDataBlock is implemented by every decoded ExtraData block
+// (ConsoleDataBlock, TrackerDataBlock, PropertyStoreDataBlock, ...).
+type ExtraDataBlock interface {
+	// Signature returns the block's 4-byte signature (MS-SHLLINK section 2.5).
+	Signature() uint32
+}
+
+// ExtraData holds the ExtraData blocks that trail a LNK file, in file order.
+type ExtraData struct {
+	Blocks []ExtraDataBlock
+}
+
+// LnkFile is the in-memory representation of a LNK file, either parsed from
+// disk or built up by a caller to be written out with WriteTo or Marshal.
+type LnkFile struct {
+	Header           ShellLinkHeader
+	LinkTargetIDList LinkTargetIDList
+	LinkInfo         LinkInfo
+	StringData       StringData
+	ExtraData        ExtraData
+
+	// SectionOffsets is only populated when the file was parsed with
+	// ParseAt; it is left zero-valued for a LnkFile built up by hand.
+	SectionOffsets SectionOffsets
+}
@@ -0,0 +1,206 @@
+package lnk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gorpher/golnk/binread"
+)
+
+// SectionOffsets records the absolute byte offset of every structural
+// section of a parsed LNK file, computed during a single forward pass over
+// the header and StringData, plus a backward scan for ExtraData (see
+// ParseAt). Callers can use these to do partial or lazy reads - for
+// example reading just the TrackerDataBlock for its forensic droid IDs -
+// without loading the whole file.
+type SectionOffsets struct {
+	Header           int64
+	LinkTargetIDList int64
+	LinkInfo         int64
+	StringData       []int64
+	ExtraData        []int64
+}
+
+const (
+	shellLinkHeaderSize = 76
+	// extraDataScanWindow is the initial backward-scan window used to find
+	// the ExtraData terminal block; it doubles until it covers every byte
+	// after StringData.
+	extraDataScanWindow = 1024
+)
+
+// ParseAt parses a LNK file from r, which has size bytes. Unlike a purely
+// sequential reader, it locates the ExtraData terminal block (the 4-byte
+// 0x00000000 that closes the section) by scanning backwards from the end
+// of the file - the same technique used to find a ZIP's end-of-central-
+// directory record - and accepting the candidate nearest EOF that the sized
+// ExtraData blocks, walked forward from the end of StringData, land on
+// exactly. Zero padding after the terminal block is tolerated, and a file
+// whose terminal block is missing or truncated is reported as an error.
+func ParseAt(r io.ReaderAt, size int64) (*LnkFile, error) {
+	if size < shellLinkHeaderSize {
+		return nil, fmt.Errorf("golnk.ParseAt: file too small to contain a header - got %d bytes", size)
+	}
+
+	l := &LnkFile{}
+	if err := binary.Read(io.NewSectionReader(r, 0, size), binary.LittleEndian, &l.Header); err != nil {
+		return nil, fmt.Errorf("golnk.ParseAt: read header - %s", err.Error())
+	}
+	l.SectionOffsets.Header = 0
+
+	offset := int64(shellLinkHeaderSize)
+	isUnicode := l.Header.LinkFlags&flagIsUnicode != 0
+
+	if l.Header.LinkFlags&flagHasLinkTargetIDList != 0 {
+		l.SectionOffsets.LinkTargetIDList = offset
+		br := binread.New(io.NewSectionReader(r, offset, size-offset))
+		idListSize, err := br.U16()
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ParseAt: read IDListSize - %s", err.Error())
+		}
+		if offset+2+int64(idListSize) > size {
+			return nil, fmt.Errorf("golnk.ParseAt: invalid IDListSize %d at offset %d", idListSize, offset)
+		}
+		itemIDList, err := br.Bytes(int(idListSize))
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ParseAt: read ItemIDList - %s", err.Error())
+		}
+		l.LinkTargetIDList = LinkTargetIDList{IDListSize: idListSize, ItemIDList: itemIDList}
+		offset += 2 + int64(idListSize)
+	}
+
+	if l.Header.LinkFlags&flagHasLinkInfo != 0 {
+		l.SectionOffsets.LinkInfo = offset
+		br := binread.New(io.NewSectionReader(r, offset, size-offset))
+		linkInfoSize, err := br.U32()
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ParseAt: read LinkInfoSize - %s", err.Error())
+		}
+		if int64(linkInfoSize) < 4 || offset+int64(linkInfoSize) > size {
+			return nil, fmt.Errorf("golnk.ParseAt: invalid LinkInfoSize %d at offset %d", linkInfoSize, offset)
+		}
+		raw, err := br.Bytes(int(linkInfoSize) - 4)
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ParseAt: read LinkInfo - %s", err.Error())
+		}
+		l.LinkInfo = LinkInfo{Raw: raw}
+		offset += int64(linkInfoSize)
+	}
+
+	for _, sd := range []struct {
+		flag uint32
+		dst  *string
+	}{
+		{flagHasName, &l.StringData.NameString},
+		{flagHasRelativePath, &l.StringData.RelativePath},
+		{flagHasWorkingDir, &l.StringData.WorkingDir},
+		{flagHasArguments, &l.StringData.CommandLineArguments},
+		{flagHasIconLocation, &l.StringData.IconLocation},
+	} {
+		if l.Header.LinkFlags&sd.flag == 0 {
+			continue
+		}
+		l.SectionOffsets.StringData = append(l.SectionOffsets.StringData, offset)
+		s, n, err := readStringDataAt(r, offset, size, isUnicode)
+		if err != nil {
+			return nil, fmt.Errorf("golnk.ParseAt: read StringData at %d - %s", offset, err.Error())
+		}
+		*sd.dst = s
+		offset += n
+	}
+
+	extraOffsets, err := scanExtraData(r, offset, size)
+	if err != nil {
+		return nil, fmt.Errorf("golnk.ParseAt: scan ExtraData - %s", err.Error())
+	}
+	l.SectionOffsets.ExtraData = extraOffsets
+
+	blocks, err := NewExtraDataIterator(io.NewSectionReader(r, offset, size-offset)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("golnk.ParseAt: decode ExtraData - %s", err.Error())
+	}
+	l.ExtraData.Blocks = blocks
+
+	return l, nil
+}
+
+// readStringDataAt reads a single StringData structure at offset and
+// returns its decoded string along with the number of bytes it occupies.
+func readStringDataAt(r io.ReaderAt, offset, size int64, isUnicode bool) (str string, n int64, err error) {
+	// Peek the character count first so we can bounds-check before
+	// binread.SizedString commits to reading the (possibly huge) body.
+	br := binread.New(io.NewSectionReader(r, offset, size-offset))
+	count, err := br.U16()
+	if err != nil {
+		return "", 0, fmt.Errorf("read count - %s", err.Error())
+	}
+	byteLen := int64(count)
+	if isUnicode {
+		byteLen *= 2
+	}
+	if offset+2+byteLen > size {
+		return "", 0, fmt.Errorf("StringData runs past end of file")
+	}
+
+	str, err = binread.New(io.NewSectionReader(r, offset, size-offset)).SizedString(isUnicode)
+	if err != nil {
+		return "", 0, fmt.Errorf("read StringData - %s", err.Error())
+	}
+	return str, 2 + byteLen, nil
+}
+
+// scanExtraData locates the ExtraData blocks between offset and the end of
+// the file. It scans backwards for 4-byte zero runs - terminal block
+// candidates - starting nearest EOF, and for each candidate tries walking
+// forward through the sized blocks from offset to see if they land on it
+// exactly. Trailing padding (sector-aligned or otherwise) can itself
+// contain zero runs that aren't the real terminator, so a candidate that
+// fails validation is discarded in favor of an earlier one rather than
+// treated as a hard error; only once every candidate in an expanded window
+// fails do we give up.
+func scanExtraData(r io.ReaderAt, offset, size int64) ([]int64, error) {
+	if size <= offset {
+		return nil, fmt.Errorf("missing ExtraData terminal block")
+	}
+
+	window := int64(extraDataScanWindow)
+	for {
+		if window > size-offset {
+			window = size - offset
+		}
+		tail := make([]byte, window)
+		if _, err := r.ReadAt(tail, size-window); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("read tail window - %s", err.Error())
+		}
+		for i := len(tail) - 4; i >= 0; i-- {
+			if tail[i] != 0 || tail[i+1] != 0 || tail[i+2] != 0 || tail[i+3] != 0 {
+				continue
+			}
+			candidate := size - window + int64(i)
+			if blockOffsets, ok := walkExtraDataBlocks(r, offset, candidate, size); ok {
+				return blockOffsets, nil
+			}
+		}
+		if window == size-offset {
+			return nil, fmt.Errorf("terminal block not found in %d trailing bytes", size-offset)
+		}
+		window *= 2
+	}
+}
+
+// walkExtraDataBlocks walks the sized ExtraData blocks forward from offset
+// and reports whether they land exactly on terminator, along with their
+// offsets if so.
+func walkExtraDataBlocks(r io.ReaderAt, offset, terminator, size int64) (blockOffsets []int64, ok bool) {
+	pos := offset
+	for pos < terminator {
+		blockSize, err := binread.New(io.NewSectionReader(r, pos, size-pos)).U32()
+		if err != nil || blockSize < 4 {
+			return nil, false
+		}
+		blockOffsets = append(blockOffsets, pos)
+		pos += int64(blockSize)
+	}
+	return blockOffsets, pos == terminator
+}
@@ -0,0 +1,50 @@
+package lnk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseAtTrailingPadding(t *testing.T) {
+	l := &LnkFile{}
+	l.StringData.NameString = "hello"
+	b, err := l.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// A realistic sector-padded file: zero bytes after the terminal block
+	// that themselves contain zero-quads.
+	padded := append(b, make([]byte, 16)...)
+
+	got, err := ParseAt(bytes.NewReader(padded), int64(len(padded)))
+	if err != nil {
+		t.Fatalf("ParseAt with trailing padding: %v", err)
+	}
+	if got.StringData.NameString != "hello" {
+		t.Fatalf("NameString = %q, want %q", got.StringData.NameString, "hello")
+	}
+}
+
+func TestParseAtMalformedInput(t *testing.T) {
+	valid, err := sampleLnkFile().Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"shorter than header", valid[:10]},
+		{"truncated mid-ExtraData block", valid[:len(valid)-40]},
+		{"missing ExtraData terminal block", valid[:len(valid)-4]},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ParseAt(bytes.NewReader(c.data), int64(len(c.data))); err == nil {
+				t.Fatalf("ParseAt(%s): want error, got nil", c.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package lnk
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// DecodeUTF16LE decodes b as UTF-16LE, the encoding used throughout LNK
+// files. Characters outside the Basic Multilingual Plane - emoji, many CJK
+// extension ideographs, some modern Windows path names - are encoded as
+// surrogate pairs and are reassembled correctly; a lone, unpaired
+// surrogate decodes to U+FFFD. A trailing odd byte, if any, is ignored.
+func DecodeUTF16LE(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// EncodeUTF16LE encodes s as UTF-16LE code units, splitting astral
+// characters into surrogate pairs as needed. It is the inverse of
+// DecodeUTF16LE.
+func EncodeUTF16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[i*2:], u)
+	}
+	return b
+}
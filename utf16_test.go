@@ -0,0 +1,56 @@
+package lnk
+
+import "testing"
+
+func TestDecodeUTF16LESurrogatePairs(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{
+			name: "bmp only",
+			in:   []byte{'h', 0, 'i', 0},
+			want: "hi",
+		},
+		{
+			// U+1F600 GRINNING FACE, encoded as the surrogate pair D83D DE00.
+			name: "astral character",
+			in:   []byte{0x3D, 0xD8, 0x00, 0xDE},
+			want: "\U0001F600",
+		},
+		{
+			name: "lone high surrogate",
+			in:   []byte{0x3D, 0xD8},
+			want: "\uFFFD",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeUTF16LE(c.in); got != c.want {
+				t.Fatalf("DecodeUTF16LE(% X) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeUTF16LERoundTrip(t *testing.T) {
+	cases := []string{
+		`C:\Users\name\Desktop\file.txt`,
+		"emoji path \U0001F600\U0001F602.txt",
+		"cjk extension b \U00020000",
+	}
+	for _, s := range cases {
+		if got := DecodeUTF16LE(EncodeUTF16LE(s)); got != s {
+			t.Fatalf("round trip of %q = %q", s, got)
+		}
+	}
+}
+
+func TestReadUnicodeStringSurrogatePairs(t *testing.T) {
+	data := append(EncodeUTF16LE("a\U0001F600"), 0x00, 0x00)
+	want := "a\U0001F600"
+	if got := readUnicodeString(data); got != want {
+		t.Fatalf("readUnicodeString(% X) = %q, want %q", data, got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package lnk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// LinkFlags bits that control which optional structures follow the header
+// (MS-SHLLINK section 2.1.1). Only the bits golnk reads or writes are named.
+const (
+	flagHasLinkTargetIDList = 1 << 0
+	flagHasLinkInfo         = 1 << 1
+	flagHasName             = 1 << 2
+	flagHasRelativePath     = 1 << 3
+	flagHasWorkingDir       = 1 << 4
+	flagHasArguments        = 1 << 5
+	flagHasIconLocation     = 1 << 6
+	flagIsUnicode           = 1 << 7
+)
+
+// shShowNormal is SW_SHOWNORMAL, the default ShowCommand (MS-SHLLINK
+// section 2.1.1).
+const shShowNormal = 1
+
+// linkCLSID is the fixed CLSID that MUST appear in every ShellLinkHeader
+// (MS-SHLLINK section 2.1): 00021401-0000-0000-C000-000000000046.
+var linkCLSID = [16]byte{
+	0x01, 0x14, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46,
+}
+
+// writeSection writes sSize bytes holding len(payload)+sSize, followed by
+// payload itself. It is the inverse of binread.Reader.Section.
+func writeSection(w io.Writer, payload []byte, sSize int) error {
+	size := uint64(len(payload) + sSize)
+	var sizeBytes []byte
+	switch sSize {
+	case 2:
+		if size > 0xFFFF {
+			return fmt.Errorf("golnk.writeSection: payload too large for a uint16 size - got %d bytes", len(payload))
+		}
+		sizeBytes = uint16Byte(uint16(size))
+	case 4:
+		if size > 0xFFFFFFFF {
+			return fmt.Errorf("golnk.writeSection: payload too large for a uint32 size - got %d bytes", len(payload))
+		}
+		sizeBytes = uint32Byte(uint32(size))
+	case 8:
+		sizeBytes = uint64Byte(size)
+	default:
+		return fmt.Errorf("golnk.writeSection: invalid sSize - got %v", sSize)
+	}
+
+	if _, err := w.Write(sizeBytes); err != nil {
+		return fmt.Errorf("golnk.writeSection: write size %d bytes - %s", sSize, err.Error())
+	}
+	if _, err := w.Write(payload); err != nil {
+		return fmt.Errorf("golnk.writeSection: write payload - %s", err.Error())
+	}
+	return nil
+}
+
+// writeStringData writes s as a StringData structure: a uint16 character
+// count followed by the (non null-terminated) string bytes, doubled for
+// unicode. It is the inverse of binread.Reader.SizedString.
+func writeStringData(w io.Writer, s string, isUnicode bool) error {
+	var b []byte
+	var count int
+	if isUnicode {
+		b = EncodeUTF16LE(s)
+		count = len(b) / 2
+	} else {
+		b = []byte(s)
+		count = len(b)
+	}
+	if count > 0xFFFF {
+		return fmt.Errorf("golnk.writeStringData: string too long - got %d characters", count)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint16(count)); err != nil {
+		return fmt.Errorf("golnk.writeStringData: write size - %s", err.Error())
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("golnk.writeStringData: write bytes - %s", err.Error())
+	}
+	return nil
+}
+
+// WriteTo encodes l as a valid LNK file and writes it to w, returning the
+// number of bytes written. StringData is always written as unicode.
+func (l *LnkFile) WriteTo(w io.Writer) (int64, error) {
+	buf := &bytes.Buffer{}
+
+	header := l.Header
+	header.HeaderSize = 76
+	header.LinkCLSID = linkCLSID
+	header.LinkFlags = l.linkFlags()
+	if header.ShowCommand == 0 {
+		header.ShowCommand = shShowNormal
+	}
+	if err := binary.Write(buf, binary.LittleEndian, &header); err != nil {
+		return 0, fmt.Errorf("golnk.WriteTo: write header - %s", err.Error())
+	}
+
+	if header.LinkFlags&flagHasLinkTargetIDList != 0 {
+		if err := binary.Write(buf, binary.LittleEndian, uint16(len(l.LinkTargetIDList.ItemIDList))); err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: write IDListSize - %s", err.Error())
+		}
+		if _, err := buf.Write(l.LinkTargetIDList.ItemIDList); err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: write ItemIDList - %s", err.Error())
+		}
+	}
+
+	if header.LinkFlags&flagHasLinkInfo != 0 {
+		if err := writeSection(buf, l.LinkInfo.Raw, 4); err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: write LinkInfo - %s", err.Error())
+		}
+	}
+
+	for _, sd := range []struct {
+		flag uint32
+		s    string
+	}{
+		{flagHasName, l.StringData.NameString},
+		{flagHasRelativePath, l.StringData.RelativePath},
+		{flagHasWorkingDir, l.StringData.WorkingDir},
+		{flagHasArguments, l.StringData.CommandLineArguments},
+		{flagHasIconLocation, l.StringData.IconLocation},
+	} {
+		if header.LinkFlags&sd.flag == 0 {
+			continue
+		}
+		if err := writeStringData(buf, sd.s, true); err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: write StringData - %s", err.Error())
+		}
+	}
+
+	for _, block := range l.ExtraData.Blocks {
+		enc, ok := block.(extraDataEncoder)
+		if !ok {
+			return 0, fmt.Errorf("golnk.WriteTo: ExtraDataBlock %T does not implement Encode", block)
+		}
+		data, err := enc.Encode()
+		if err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: encode ExtraDataBlock %T - %s", block, err.Error())
+		}
+		payload := append(uint32Byte(block.Signature()), data...)
+		if err := writeSection(buf, payload, 4); err != nil {
+			return 0, fmt.Errorf("golnk.WriteTo: write ExtraDataBlock %T - %s", block, err.Error())
+		}
+	}
+	// Terminal block: a single 0x00000000 size closes the ExtraData section.
+	if err := binary.Write(buf, binary.LittleEndian, uint32(0)); err != nil {
+		return 0, fmt.Errorf("golnk.WriteTo: write terminal block - %s", err.Error())
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Marshal encodes l as a valid LNK file and returns the resulting bytes.
+func (l *LnkFile) Marshal() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if _, err := l.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// linkFlags derives the LinkFlags bits from which optional structures are
+// populated, so callers do not have to keep them in sync by hand.
+func (l *LnkFile) linkFlags() uint32 {
+	flags := l.Header.LinkFlags &^ (flagHasLinkTargetIDList | flagHasLinkInfo | flagHasName |
+		flagHasRelativePath | flagHasWorkingDir | flagHasArguments | flagHasIconLocation | flagIsUnicode)
+
+	if len(l.LinkTargetIDList.ItemIDList) > 0 {
+		flags |= flagHasLinkTargetIDList
+	}
+	if len(l.LinkInfo.Raw) > 0 {
+		flags |= flagHasLinkInfo
+	}
+	if l.StringData.NameString != "" {
+		flags |= flagHasName
+	}
+	if l.StringData.RelativePath != "" {
+		flags |= flagHasRelativePath
+	}
+	if l.StringData.WorkingDir != "" {
+		flags |= flagHasWorkingDir
+	}
+	if l.StringData.CommandLineArguments != "" {
+		flags |= flagHasArguments
+	}
+	if l.StringData.IconLocation != "" {
+		flags |= flagHasIconLocation
+	}
+	flags |= flagIsUnicode
+
+	return flags
+}
+
+// extraDataEncoder is implemented by ExtraDataBlock values that know how to
+// serialize themselves back to bytes.
+type extraDataEncoder interface {
+	ExtraDataBlock
+	Encode() ([]byte, error)
+}
@@ -0,0 +1,59 @@
+package lnk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func sampleLnkFile() *LnkFile {
+	l := &LnkFile{}
+	l.LinkTargetIDList.ItemIDList = []byte{0x01, 0x02, 0x03}
+	l.LinkInfo.Raw = []byte{0xAA, 0xBB, 0xCC, 0xDD}
+	l.StringData.NameString = "hello"
+	l.StringData.RelativePath = `.\target.txt`
+	l.StringData.WorkingDir = `C:\Users\name`
+	l.StringData.CommandLineArguments = "--flag \U0001F600"
+	l.StringData.IconLocation = `C:\Windows\System32\shell32.dll`
+	l.ExtraData.Blocks = []ExtraDataBlock{
+		ConsoleDataBlock{Raw: bytes.Repeat([]byte{0x01}, 96)},
+		TrackerDataBlock{Raw: bytes.Repeat([]byte{0x02}, 58)},
+	}
+	return l
+}
+
+func TestMarshalParseAtRoundTrip(t *testing.T) {
+	want := sampleLnkFile()
+	b, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	got, err := ParseAt(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("ParseAt: %v", err)
+	}
+
+	if got.Header.LinkCLSID != linkCLSID {
+		t.Errorf("Header.LinkCLSID = % X, want % X", got.Header.LinkCLSID, linkCLSID)
+	}
+	if got.Header.ShowCommand != shShowNormal {
+		t.Errorf("Header.ShowCommand = %d, want %d", got.Header.ShowCommand, shShowNormal)
+	}
+	if got.StringData != want.StringData {
+		t.Fatalf("StringData = %+v, want %+v", got.StringData, want.StringData)
+	}
+	if !bytes.Equal(got.LinkTargetIDList.ItemIDList, want.LinkTargetIDList.ItemIDList) {
+		t.Fatalf("ItemIDList = % X, want % X", got.LinkTargetIDList.ItemIDList, want.LinkTargetIDList.ItemIDList)
+	}
+	if !bytes.Equal(got.LinkInfo.Raw, want.LinkInfo.Raw) {
+		t.Fatalf("LinkInfo.Raw = % X, want % X", got.LinkInfo.Raw, want.LinkInfo.Raw)
+	}
+	if len(got.ExtraData.Blocks) != len(want.ExtraData.Blocks) {
+		t.Fatalf("ExtraData.Blocks = %d blocks, want %d", len(got.ExtraData.Blocks), len(want.ExtraData.Blocks))
+	}
+	for i, block := range got.ExtraData.Blocks {
+		if block.Signature() != want.ExtraData.Blocks[i].Signature() {
+			t.Errorf("block %d signature = 0x%08X, want 0x%08X", i, block.Signature(), want.ExtraData.Blocks[i].Signature())
+		}
+	}
+}